@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// runEdit implements `gomodpin edit`: it mutates only the managed replace block, adding or
+// updating one entry per -replace path@version, and dropping one entry per -dropreplace
+// path, without recomputing the rest of the pin set. Modeled after `go mod edit -replace`.
+// It refuses to touch a path whose existing replace isn't an unconditional self-replace, so
+// it never clobbers or drops a hand-written override like a local directory fork.
+func runEdit(args []string) {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	var verbose bool
+	var replaceArgs stringSliceFlag
+	var dropReplaceArgs stringSliceFlag
+
+	fs.BoolVar(&verbose, "v", false, "enable verbose logs")
+	fs.Var(&replaceArgs, "replace", "add or update a managed replace entry as path@version; can be repeated")
+	fs.Var(&dropReplaceArgs, "dropreplace", "drop a managed replace entry by path; can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s edit [flags] /path/to/go.mod\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || (len(replaceArgs) == 0 && len(dropReplaceArgs) == 0) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	modPath := fs.Arg(0)
+	info, err := os.Stat(modPath)
+	if err != nil {
+		log.Fatalf("error accessing path: %v", err)
+	}
+	if info.IsDir() || filepath.Base(modPath) != "go.mod" {
+		log.Fatalf("provided path must be a go.mod file; got %q", modPath)
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		log.Fatalf("error reading go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		log.Fatalf("error parsing go.mod: %v", err)
+	}
+
+	changed := 0
+	for _, spec := range replaceArgs {
+		path, version, ok := strings.Cut(spec, "@")
+		if !ok || path == "" || version == "" {
+			log.Fatalf("invalid -replace value %q; want path@version", spec)
+		}
+		if existing := findReplace(f, path); existing != nil && !isManagedSelfReplace(existing) {
+			log.Fatalf("refusing to overwrite unmanaged replace for %s (not a gomodpin self-pin); edit go.mod directly", path)
+		}
+		if err := f.AddReplace(path, "", path, version); err != nil {
+			log.Fatalf("error adding replace for %s: %v", path, err)
+		}
+		markPinComment(f, path)
+		changed++
+		if verbose {
+			fmt.Printf("Replacing: %s with %s\n", path, version)
+		}
+	}
+	for _, path := range dropReplaceArgs {
+		existing := findReplace(f, path)
+		if existing == nil {
+			log.Fatalf("no replace found for %s", path)
+		}
+		if !isManagedSelfReplace(existing) {
+			log.Fatalf("refusing to drop unmanaged replace for %s (not a gomodpin self-pin); edit go.mod directly", path)
+		}
+		if err := f.DropReplace(path, ""); err != nil {
+			log.Fatalf("error dropping replace for %s: %v", path, err)
+		}
+		changed++
+		if verbose {
+			fmt.Printf("Dropped replace for %s\n", path)
+		}
+	}
+
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		log.Fatalf("error formatting go.mod: %v", err)
+	}
+	if err := writeFileAtomically(modPath, out, info.Mode().Perm()); err != nil {
+		log.Fatalf("error writing go.mod: %v", err)
+	}
+	if verbose {
+		fmt.Printf("Applied %d edits to %s\n", changed, modPath)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// runList implements `gomodpin list`: it prints the modules that `gomodpin pin` would
+// write a replace for, honoring the same excludes, one "path version" pair per line.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	var verbose bool
+	var noDefaultExcludes bool
+	var userExcludes stringSliceFlag
+
+	fs.BoolVar(&verbose, "v", false, "enable verbose logs")
+	fs.BoolVar(&noDefaultExcludes, "no-default-excludes", false, "disable default excludes (dd-trace-go and orchestrion)")
+	fs.Var(&userExcludes, "exclude", "module path to exclude; can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s list [flags] /path/to/go.mod\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	modPath := fs.Arg(0)
+	if filepath.Base(modPath) != "go.mod" {
+		log.Fatalf("provided path must be a go.mod file; got %q", modPath)
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		log.Fatalf("error reading go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		log.Fatalf("error parsing go.mod: %v", err)
+	}
+
+	modules := computeModules(f, verbose)
+	applyExcludeSet(modules, buildExcludeSet(noDefaultExcludes, userExcludes), verbose)
+
+	processMapOrdered(modules, func(path, version string) {
+		if version == "" {
+			return
+		}
+		fmt.Printf("%s %s\n", path, version)
+	})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/mod/modfile"
+)
+
+// runPin implements `gomodpin pin`: it pins every module in a go.mod (or every module used
+// by a go.work) to its currently resolved version.
+func runPin(args []string) {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	var verbose bool
+	var noDefaultExcludes bool
+	var userExcludes stringSliceFlag
+	var workspace bool
+	var jsonOutput bool
+	var offline bool
+	var failOnDeprecated bool
+
+	fs.BoolVar(&verbose, "v", false, "enable verbose logs")
+	fs.BoolVar(&noDefaultExcludes, "no-default-excludes", false, "disable default excludes (dd-trace-go and orchestrion)")
+	fs.Var(&userExcludes, "exclude", "module path to exclude; can be repeated")
+	fs.BoolVar(&workspace, "workspace", false, "when the target is a go.work file, reconcile a single winning version per module across the whole workspace")
+	fs.BoolVar(&jsonOutput, "json", false, "print a JSON report of the pinned modules to stdout instead of verbose logs")
+	fs.BoolVar(&offline, "offline", false, "skip checking the module proxy for deprecated modules")
+	fs.BoolVar(&failOnDeprecated, "fail-on-deprecated", false, "exit non-zero without writing the pin block if any pinned module is deprecated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s pin [flags] /path/to/go.mod|go.work\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Exactly one positional argument: the path to the target go.mod or go.work.
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	modPath := fs.Arg(0)
+
+	// Validate the provided path points to a file. Fail early with clear errors to make it
+	// easy to fix invocation issues.
+	info, err := os.Stat(modPath)
+	if err != nil {
+		log.Fatalf("error accessing path: %v", err)
+	}
+	if info.IsDir() {
+		log.Fatalf("provided path is a directory; expected path to a go.mod or go.work file")
+	}
+
+	excludeSet := buildExcludeSet(noDefaultExcludes, userExcludes)
+
+	if filepath.Base(modPath) == "go.work" {
+		if jsonOutput {
+			log.Fatalf("-json is not supported together with a go.work target")
+		}
+		if err := pinWorkspace(modPath, verbose, workspace, excludeSet, offline, failOnDeprecated); err != nil {
+			log.Fatalf("error pinning workspace: %v", err)
+		}
+		return
+	}
+
+	if filepath.Base(modPath) != "go.mod" {
+		log.Fatalf("provided path must be a go.mod or go.work file; got %q", filepath.Base(modPath))
+	}
+
+	// Read the file contents and parse using x/mod/modfile to inspect requires, replaces, and excludes.
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		log.Fatalf("error reading go.mod: %v", err)
+	}
+
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		log.Fatalf("error parsing go.mod: %v", err)
+	}
+
+	// Build the JSON report from the file's pristine state, before pinModule mutates f by
+	// adding replace directives.
+	var report []jsonPinEntry
+	if jsonOutput {
+		report = buildPinReport(f, noDefaultExcludes, userExcludes)
+	}
+
+	modules := computeModules(f, verbose && !jsonOutput)
+	applyExcludeSet(modules, excludeSet, verbose && !jsonOutput)
+
+	if !offline {
+		checkModulesForDeprecation(modules, verbose, failOnDeprecated)
+	}
+
+	count, err := pinModule(modPath, data, info, f, modules, verbose && !jsonOutput)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if jsonOutput {
+		printPinReport(report, filepath.Join(filepath.Dir(modPath), "go.mod.old"))
+		return
+	}
+
+	if verbose {
+		if count == 0 {
+			fmt.Printf("No replacements to add\n")
+		} else {
+			fmt.Printf("Added %d replacements to %s\n", count, modPath)
+		}
+	}
+}
+
+// jsonPinEntry is the -json report for a single module required by the target go.mod.
+type jsonPinEntry struct {
+	Path          string `json:"Path"`
+	Version       string `json:"Version,omitempty"`
+	Replacement   string `json:"Replacement,omitempty"`
+	Excluded      bool   `json:"Excluded"`
+	ExcludeReason string `json:"ExcludeReason,omitempty"`
+	Skipped       bool   `json:"Skipped"`
+	SkipReason    string `json:"SkipReason,omitempty"`
+}
+
+// jsonPinSummary is the final object printed under -json, after the per-module array.
+type jsonPinSummary struct {
+	Total      int    `json:"Total"`
+	Pinned     int    `json:"Pinned"`
+	Excluded   int    `json:"Excluded"`
+	Skipped    int    `json:"Skipped"`
+	BackupPath string `json:"BackupPath,omitempty"`
+}
+
+// classifyExclude reports whether path is excluded by the default excludes or by a
+// user-provided -exclude flag, and which one, for the -json ExcludeReason field.
+func classifyExclude(path string, noDefaultExcludes bool, userExcludes []string) (bool, string) {
+	if !noDefaultExcludes {
+		for _, p := range defaultExcludes {
+			if p == path {
+				return true, "default"
+			}
+		}
+	}
+	for _, p := range userExcludes {
+		if p == path {
+			return true, "flag"
+		}
+	}
+	return false, ""
+}
+
+// buildPinReport mirrors computeModules' resolution (require + replace + go.mod exclude),
+// but keeps a report entry per module path instead of collapsing straight to a version map,
+// so -json can describe why each module was excluded or skipped.
+func buildPinReport(f *modfile.File, noDefaultExcludes bool, userExcludes []string) []jsonPinEntry {
+	versions := make(map[string]string)
+	for _, require := range f.Require {
+		versions[require.Mod.Path] = require.Mod.Version
+	}
+
+	goModExcludes := make(map[string]bool)
+	for _, exclude := range f.Exclude {
+		goModExcludes[exclude.Mod.Path] = true
+	}
+
+	for _, replace := range f.Replace {
+		if replace.Old.Path != replace.New.Path {
+			// Overridden by a dependency swap rather than a pin; nothing to report.
+			delete(versions, replace.Old.Path)
+		} else {
+			versions[replace.Old.Path] = replace.New.Version
+		}
+	}
+
+	paths := make([]string, 0, len(versions))
+	for path := range versions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	entries := make([]jsonPinEntry, 0, len(paths))
+	for _, path := range paths {
+		entry := jsonPinEntry{Path: path}
+
+		switch {
+		case goModExcludes[path]:
+			entry.Excluded = true
+			entry.ExcludeReason = "go.mod-exclude"
+		default:
+			if matched, reason := classifyExclude(path, noDefaultExcludes, userExcludes); matched {
+				entry.Excluded = true
+				entry.ExcludeReason = reason
+			}
+		}
+
+		if !entry.Excluded {
+			if version := versions[path]; version == "" {
+				entry.Skipped = true
+				entry.SkipReason = "empty-version"
+			} else {
+				entry.Version = version
+				entry.Replacement = path
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// printPinReport writes the -json array of per-module entries followed by a summary object.
+func printPinReport(entries []jsonPinEntry, backupPath string) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entries); err != nil {
+		log.Fatalf("error encoding JSON report: %v", err)
+	}
+
+	summary := jsonPinSummary{Total: len(entries), BackupPath: backupPath}
+	for _, e := range entries {
+		switch {
+		case e.Excluded:
+			summary.Excluded++
+		case e.Skipped:
+			summary.Skipped++
+		default:
+			summary.Pinned++
+		}
+	}
+	if err := enc.Encode(summary); err != nil {
+		log.Fatalf("error encoding JSON summary: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// -json must report one entry per required module, classify the default-excluded one, and
+// emit a trailing summary object naming the backup it wrote.
+func TestRunPin_JSONReportsModulesAndSummary(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod+"\nrequire gopkg.in/DataDog/dd-trace-go.v1 v1.59.0\n")
+
+	out := captureStdout(t, func() { runPin([]string{"-json", modPath}) })
+
+	dec := json.NewDecoder(strings.NewReader(out))
+
+	var entries []jsonPinEntry
+	if err := dec.Decode(&entries); err != nil {
+		t.Fatalf("decode entries: %v", err)
+	}
+	var summary jsonPinSummary
+	if err := dec.Decode(&summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+
+	byPath := make(map[string]jsonPinEntry, len(entries))
+	for _, e := range entries {
+		byPath[e.Path] = e
+	}
+
+	errorsEntry, ok := byPath["github.com/pkg/errors"]
+	if !ok {
+		t.Fatalf("missing entry for pkg/errors")
+	}
+	if errorsEntry.Excluded || errorsEntry.Skipped || errorsEntry.Version != "v0.9.1" || errorsEntry.Replacement != "github.com/pkg/errors" {
+		t.Fatalf("unexpected entry for pkg/errors: %+v", errorsEntry)
+	}
+
+	ddEntry, ok := byPath["gopkg.in/DataDog/dd-trace-go.v1"]
+	if !ok {
+		t.Fatalf("missing entry for dd-trace-go")
+	}
+	if !ddEntry.Excluded || ddEntry.ExcludeReason != "default" {
+		t.Fatalf("expected dd-trace-go excluded by default, got %+v", ddEntry)
+	}
+
+	if summary.Total != len(entries) || summary.Pinned != 2 || summary.Excluded != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if !strings.HasSuffix(summary.BackupPath, "go.mod.old") {
+		t.Fatalf("expected backup path to point at go.mod.old, got %q", summary.BackupPath)
+	}
+}
+
+// -v must warn about a deprecated module on stderr, via the real GOPROXY lookup path, and
+// still write the pin since -fail-on-deprecated was not given.
+func TestRunPin_WarnsOnDeprecatedModule(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/github.com/pkg/errors/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version":"v0.9.1"}`)
+	})
+	mux.HandleFunc("/github.com/pkg/errors/@v/v0.9.1.mod", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "// Deprecated: use errors/fmt instead\nmodule github.com/pkg/errors\n")
+	})
+	mux.HandleFunc("/golang.org/x/sys/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version":"v0.16.0"}`)
+	})
+	mux.HandleFunc("/golang.org/x/sys/@v/v0.16.0.mod", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "module golang.org/x/sys\n")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("GOPROXY", srv.URL)
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	modPath := writeGoMod(t, fixtureGoMod)
+
+	stderr := captureStderr(t, func() { runPin([]string{"-v", modPath}) })
+
+	if !strings.Contains(stderr, "warning: pinning deprecated module github.com/pkg/errors: use errors/fmt instead") {
+		t.Fatalf("missing deprecation warning in stderr: %q", stderr)
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod: %v", err)
+	}
+	if !strings.Contains(string(data), "github.com/pkg/errors => github.com/pkg/errors v0.9.1") {
+		t.Fatalf("expected pin to still be written despite the warning:\n%s", data)
+	}
+}
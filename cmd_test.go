@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+// captureStdout redirects os.Stdout for the duration of fn and returns what was written.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	orig := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("create pipe: %v", err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read pipe: %v", err)
+	}
+	return string(out)
+}
+
+// captureStderr redirects os.Stderr for the duration of fn and returns what was written.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	orig := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("create pipe: %v", err)
+	}
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	fn()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read pipe: %v", err)
+	}
+	return string(out)
+}
+
+func writeGoMod(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	modPath := filepath.Join(dir, "go.mod")
+	if err := os.WriteFile(modPath, []byte(strings.TrimSpace(content)+"\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	return modPath
+}
+
+const fixtureGoMod = `
+module example.com/test
+
+go 1.23.2
+
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/sys v0.16.0
+)
+`
+
+// computeExpectedModules must ignore a gomodpin-managed self-replace (use the require
+// version instead) but still honor a hand-written self-replace as an intentional override.
+func TestComputeExpectedModules_IgnoresOwnPinButHonorsManualSelfReplace(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("parse go.mod: %v", err)
+	}
+
+	// Simulate a stale gomodpin pin: require was bumped to v0.9.1 but the managed replace
+	// still points at the older v0.9.0.
+	if err := f.AddReplace("github.com/pkg/errors", "", "github.com/pkg/errors", "v0.9.0"); err != nil {
+		t.Fatalf("add replace: %v", err)
+	}
+	markPinComment(f, "github.com/pkg/errors")
+
+	// A hand-written pin for the other module, with no marker, should still win.
+	if err := f.AddReplace("golang.org/x/sys", "", "golang.org/x/sys", "v0.15.0"); err != nil {
+		t.Fatalf("add replace: %v", err)
+	}
+
+	expected := computeExpectedModules(f, false)
+	if expected["github.com/pkg/errors"] != "v0.9.1" {
+		t.Fatalf("expected pkg/errors to fall back to require version v0.9.1, got %s", expected["github.com/pkg/errors"])
+	}
+	if expected["golang.org/x/sys"] != "v0.15.0" {
+		t.Fatalf("expected hand-written self-replace to win, got %s", expected["golang.org/x/sys"])
+	}
+}
+
+// A freshly pinned go.mod must verify as up to date.
+func TestRunVerify_UpToDateAfterPin(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+
+	runPin([]string{modPath})
+	runVerify([]string{modPath})
+}
+
+// Bumping a require's version after pinning must make verify report the go.mod as stale,
+// without actually rewriting it.
+func TestVerifyModule_DetectsStaleRequireBump(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+	runPin([]string{modPath})
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("parse go.mod: %v", err)
+	}
+	if err := f.AddRequire("github.com/pkg/errors", "v0.9.2"); err != nil {
+		t.Fatalf("bump require: %v", err)
+	}
+	f.Cleanup()
+	bumped, err := f.Format()
+	if err != nil {
+		t.Fatalf("format go.mod: %v", err)
+	}
+	if err := os.WriteFile(modPath, bumped, 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	data, err = os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("re-read go.mod: %v", err)
+	}
+	f, err = modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("re-parse go.mod: %v", err)
+	}
+
+	wantBytes, stale, err := verifyModule(modPath, data, f, buildExcludeSet(false, nil), false)
+	if err != nil {
+		t.Fatalf("verifyModule: %v", err)
+	}
+	if !stale {
+		t.Fatalf("expected go.mod to be reported stale after a require bump")
+	}
+	if !strings.Contains(string(wantBytes), "github.com/pkg/errors => github.com/pkg/errors v0.9.2") {
+		t.Fatalf("expected wantBytes to reflect the bumped version:\n%s", wantBytes)
+	}
+
+	// verifyModule must not have mutated the on-disk file.
+	unchanged, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod after verify: %v", err)
+	}
+	if string(unchanged) != string(data) {
+		t.Fatalf("verifyModule must not rewrite go.mod")
+	}
+}
+
+// printLineDiff must mark lines only present in got with "-" and lines only present in want
+// with "+".
+func TestPrintLineDiff_MarksAddedAndRemovedLines(t *testing.T) {
+	var buf bytes.Buffer
+	printLineDiff(&buf, "same\nold line\n", "same\nnew line\n")
+
+	out := buf.String()
+	if !strings.Contains(out, "-old line") {
+		t.Fatalf("expected removed line marker in diff: %q", out)
+	}
+	if !strings.Contains(out, "+new line") {
+		t.Fatalf("expected added line marker in diff: %q", out)
+	}
+}
+
+// list must print one "path version" line per pinnable module, honoring default excludes.
+func TestRunList_PrintsModulesHonoringExcludes(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod+"\nrequire gopkg.in/DataDog/dd-trace-go.v1 v1.59.0\n")
+
+	out := captureStdout(t, func() { runList([]string{modPath}) })
+
+	if !strings.Contains(out, "github.com/pkg/errors v0.9.1") {
+		t.Fatalf("missing pkg/errors in list output: %q", out)
+	}
+	if strings.Contains(out, "dd-trace-go") {
+		t.Fatalf("default-excluded module appeared in list output: %q", out)
+	}
+}
+
+// unpin must restore the original go.mod from its backup and remove the backup file.
+func TestRunUnpin_RestoresFromBackup(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+	original, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read original: %v", err)
+	}
+
+	runPin([]string{modPath})
+	runUnpin([]string{modPath})
+
+	restored, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read restored: %v", err)
+	}
+	if string(restored) != string(original) {
+		t.Fatalf("restored go.mod does not match original:\ngot:\n%s\nwant:\n%s", restored, original)
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(modPath), "go.mod.old")); !os.IsNotExist(err) {
+		t.Fatalf("expected backup to be removed after unpin, stat err: %v", err)
+	}
+}
+
+// unpin without a backup must fall back to dropping only marker-bearing self-replaces,
+// leaving a hand-written self-replace (one gomodpin never wrote) untouched.
+func TestRunUnpin_NoBackupFallbackPreservesManualSelfReplace(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("parse go.mod: %v", err)
+	}
+
+	if err := f.AddReplace("github.com/pkg/errors", "", "github.com/pkg/errors", "v0.9.0"); err != nil {
+		t.Fatalf("add replace: %v", err)
+	}
+	markPinComment(f, "github.com/pkg/errors")
+
+	// A hand-written self-replace, with no marker, should survive unpin.
+	if err := f.AddReplace("golang.org/x/sys", "", "golang.org/x/sys", "v1.2.3"); err != nil {
+		t.Fatalf("add replace: %v", err)
+	}
+
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		t.Fatalf("format go.mod: %v", err)
+	}
+	if err := os.WriteFile(modPath, out, 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	runUnpin([]string{modPath})
+
+	data, err = os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read unpinned go.mod: %v", err)
+	}
+	f, err = modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("parse unpinned go.mod: %v", err)
+	}
+
+	var sawErrors, sawSys bool
+	for _, r := range f.Replace {
+		switch r.Old.Path {
+		case "github.com/pkg/errors":
+			sawErrors = true
+		case "golang.org/x/sys":
+			sawSys = true
+			if r.New.Version != "v1.2.3" {
+				t.Fatalf("expected hand-written x/sys replace to keep v1.2.3, got %s", r.New.Version)
+			}
+		}
+	}
+	if sawErrors {
+		t.Fatalf("marker-bearing pkg/errors replace should have been dropped")
+	}
+	if !sawSys {
+		t.Fatalf("hand-written x/sys replace should have been preserved")
+	}
+}
+
+// edit must add the requested replace and drop the requested one, leaving the rest alone.
+func TestRunEdit_AddAndDropReplace(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+
+	runPin([]string{modPath})
+	runEdit([]string{"-replace", "github.com/pkg/errors@v0.9.2", "-dropreplace", "golang.org/x/sys", modPath})
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("parse go.mod: %v", err)
+	}
+
+	var sawErrors, sawSys bool
+	for _, r := range f.Replace {
+		switch r.Old.Path {
+		case "github.com/pkg/errors":
+			sawErrors = true
+			if r.New.Version != "v0.9.2" {
+				t.Fatalf("expected pkg/errors replaced with v0.9.2, got %s", r.New.Version)
+			}
+		case "golang.org/x/sys":
+			sawSys = true
+		}
+	}
+	if !sawErrors {
+		t.Fatalf("missing edited replace for pkg/errors")
+	}
+	if sawSys {
+		t.Fatalf("x/sys replace should have been dropped")
+	}
+}
+
+// isManagedSelfReplace must only treat an unconditional self-replace as gomodpin's own pin
+// shape, so edit refuses to clobber a local directory override (a replace to a different
+// path, e.g. a local fork) that happens to share the target path.
+func TestIsManagedSelfReplace_RejectsLocalDirectoryOverride(t *testing.T) {
+	modPath := writeGoMod(t, fixtureGoMod)
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		t.Fatalf("read go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		t.Fatalf("parse go.mod: %v", err)
+	}
+
+	if err := f.AddReplace("github.com/pkg/errors", "", "../local-errors-fork", ""); err != nil {
+		t.Fatalf("add replace: %v", err)
+	}
+
+	localFork := findReplace(f, "github.com/pkg/errors")
+	if localFork == nil {
+		t.Fatalf("expected to find the local fork replace")
+	}
+	if isManagedSelfReplace(localFork) {
+		t.Fatalf("local directory override must not be treated as a gomodpin self-pin")
+	}
+
+	if err := f.DropReplace("github.com/pkg/errors", ""); err != nil {
+		t.Fatalf("drop replace: %v", err)
+	}
+	if err := f.AddReplace("github.com/pkg/errors", "", "github.com/pkg/errors", "v0.9.2"); err != nil {
+		t.Fatalf("add replace: %v", err)
+	}
+	markPinComment(f, "github.com/pkg/errors")
+
+	selfPin := findReplace(f, "github.com/pkg/errors")
+	if selfPin == nil {
+		t.Fatalf("expected to find the self-pin replace")
+	}
+	if !isManagedSelfReplace(selfPin) {
+		t.Fatalf("self-replace must be treated as a gomodpin self-pin")
+	}
+}
+
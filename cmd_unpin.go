@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// runUnpin implements `gomodpin unpin`: it removes a gomodpin-managed pin block from a
+// go.mod. If a go.mod.old backup sits next to the target, that backup is restored verbatim
+// and removed, which undoes a pin exactly. Otherwise it falls back to dropping every
+// self-replace (replace X => X vN with no old version) that still carries gomodpin's
+// pinMarker comment; hand-written self-replaces and replaces to a different path or a
+// local directory are left untouched, matching computeExpectedModules's treatment of
+// unmarked self-replaces as an intentional pin gomodpin didn't write.
+func runUnpin(args []string) {
+	fs := flag.NewFlagSet("unpin", flag.ExitOnError)
+	var verbose bool
+	fs.BoolVar(&verbose, "v", false, "enable verbose logs")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s unpin [flags] /path/to/go.mod\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	modPath := fs.Arg(0)
+	info, err := os.Stat(modPath)
+	if err != nil {
+		log.Fatalf("error accessing path: %v", err)
+	}
+	if info.IsDir() || filepath.Base(modPath) != "go.mod" {
+		log.Fatalf("provided path must be a go.mod file; got %q", modPath)
+	}
+
+	backupPath := filepath.Join(filepath.Dir(modPath), "go.mod.old")
+	if backupData, err := os.ReadFile(backupPath); err == nil {
+		if err := writeFileAtomically(modPath, backupData, info.Mode().Perm()); err != nil {
+			log.Fatalf("error restoring %s: %v", modPath, err)
+		}
+		if err := os.Remove(backupPath); err != nil && verbose {
+			fmt.Printf("warning: could not remove backup %s: %v\n", backupPath, err)
+		}
+		if verbose {
+			fmt.Printf("Restored %s from %s\n", modPath, backupPath)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		log.Fatalf("error reading go.mod: %v", err)
+	}
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		log.Fatalf("error parsing go.mod: %v", err)
+	}
+
+	var managed []string
+	for _, r := range f.Replace {
+		if r.Old.Path == r.New.Path && r.Old.Version == "" && hasPinMarker(r) {
+			managed = append(managed, r.Old.Path)
+		}
+	}
+	if len(managed) == 0 {
+		if verbose {
+			fmt.Printf("No gomodpin-managed replacements found in %s\n", modPath)
+		}
+		return
+	}
+
+	for _, path := range managed {
+		if err := f.DropReplace(path, ""); err != nil {
+			log.Fatalf("error dropping replace for %s: %v", path, err)
+		}
+	}
+
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		log.Fatalf("error formatting go.mod: %v", err)
+	}
+	if err := writeFileAtomically(modPath, out, info.Mode().Perm()); err != nil {
+		log.Fatalf("error writing go.mod: %v", err)
+	}
+	if verbose {
+		fmt.Printf("Removed %d gomodpin-managed replacements from %s\n", len(managed), modPath)
+	}
+}
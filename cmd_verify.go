@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// verifyModule recomputes what the managed pin block in f should contain and compares it
+// against data, the on-disk bytes f was parsed from. It returns the bytes go.mod should
+// contain, whether that differs from data (stale), and any error building the expected state.
+// Split out from runVerify so the stale-detection path can be exercised without the process
+// exit runVerify performs on a mismatch.
+func verifyModule(modPath string, data []byte, f *modfile.File, excludeSet map[string]struct{}, verbose bool) (wantBytes []byte, stale bool, err error) {
+	expectedModules := computeExpectedModules(f, verbose)
+	applyExcludeSet(expectedModules, excludeSet, verbose)
+
+	// Re-parse a fresh copy so AddReplace starts from the same on-disk state but doesn't
+	// disturb the File we already inspected, then build what the file should look like.
+	want, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing go.mod: %w", err)
+	}
+	var addErr error
+	processMapOrdered(expectedModules, func(path, version string) {
+		if version == "" || addErr != nil {
+			return
+		}
+		if err := want.AddReplace(path, "", path, version); err != nil {
+			addErr = fmt.Errorf("error adding replace for %s: %w", path, err)
+		}
+	})
+	if addErr != nil {
+		return nil, false, addErr
+	}
+	want.Cleanup()
+	wantBytes, err = want.Format()
+	if err != nil {
+		return nil, false, fmt.Errorf("error formatting go.mod: %w", err)
+	}
+
+	return wantBytes, !bytes.Equal(wantBytes, data), nil
+}
+
+// runVerify implements `gomodpin verify`: it recomputes what the managed pin block should
+// contain and compares it against the on-disk go.mod, exiting non-zero with a diff if they
+// differ. This is meant for CI, to catch a go.mod that was edited (or had its requires
+// bumped) without re-running `gomodpin pin`.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	var verbose bool
+	var noDefaultExcludes bool
+	var userExcludes stringSliceFlag
+
+	fs.BoolVar(&verbose, "v", false, "enable verbose logs")
+	fs.BoolVar(&noDefaultExcludes, "no-default-excludes", false, "disable default excludes (dd-trace-go and orchestrion)")
+	fs.Var(&userExcludes, "exclude", "module path to exclude; can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify [flags] /path/to/go.mod\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	modPath := fs.Arg(0)
+	if filepath.Base(modPath) != "go.mod" {
+		log.Fatalf("provided path must be a go.mod file; got %q", modPath)
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		log.Fatalf("error reading go.mod: %v", err)
+	}
+
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		log.Fatalf("error parsing go.mod: %v", err)
+	}
+
+	excludeSet := buildExcludeSet(noDefaultExcludes, userExcludes)
+	wantBytes, stale, err := verifyModule(modPath, data, f, excludeSet, verbose)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if !stale {
+		if verbose {
+			fmt.Printf("%s is up to date\n", modPath)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%s is stale; re-run `gomodpin pin %s`\n", modPath, modPath)
+	printLineDiff(os.Stderr, string(data), string(wantBytes))
+	os.Exit(1)
+}
+
+// printLineDiff prints a minimal line-oriented diff between got and want: lines present in
+// got but not want are prefixed "-", lines present in want but not got are prefixed "+".
+// It is not a true longest-common-subsequence diff, but it is enough to point at what
+// changed without pulling in a diff dependency.
+func printLineDiff(w io.Writer, got, want string) {
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(want, "\n")
+
+	gotSet := make(map[string]bool, len(gotLines))
+	for _, l := range gotLines {
+		gotSet[l] = true
+	}
+	wantSet := make(map[string]bool, len(wantLines))
+	for _, l := range wantLines {
+		wantSet[l] = true
+	}
+
+	for _, l := range gotLines {
+		if !wantSet[l] {
+			fmt.Fprintf(w, "-%s\n", l)
+		}
+	}
+	for _, l := range wantLines {
+		if !gotSet[l] {
+			fmt.Fprintf(w, "+%s\n", l)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// proxyClient is a small client for the subset of the module proxy protocol
+// (https://go.dev/ref/mod#goproxy-protocol) gomodpin needs to look up a module's latest
+// go.mod: GET <proxy>/<path>/@latest, then GET <proxy>/<path>/@v/<version>.mod.
+//
+// It honors GOPROXY the way cmd/go does: a comma-separated list tried in order, where
+// "off" disables lookups entirely and "direct" (falling back to a VCS fetch) isn't
+// implemented here, so it is skipped rather than attempted.
+type proxyClient struct {
+	httpClient *http.Client
+	proxies    []string
+	cacheDir   string
+	verbose    bool
+}
+
+// newProxyClient builds a proxyClient from the GOPROXY and GOMODCACHE environment, matching
+// cmd/go's own defaults when those variables are unset.
+func newProxyClient(verbose bool) *proxyClient {
+	return &proxyClient{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		proxies:    parseGoProxy(os.Getenv("GOPROXY")),
+		cacheDir:   moduleDownloadCacheDir(),
+		verbose:    verbose,
+	}
+}
+
+// parseGoProxy splits a GOPROXY value into the ordered list of proxies cmd/go would try,
+// defaulting to the same value "go env" would report when GOPROXY is unset.
+func parseGoProxy(raw string) []string {
+	if raw == "" {
+		raw = "https://proxy.golang.org,direct"
+	}
+	var proxies []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		proxies = append(proxies, part)
+		if part == "off" {
+			break
+		}
+	}
+	return proxies
+}
+
+// moduleDownloadCacheDir returns $GOMODCACHE/cache/download, falling back to
+// $GOPATH/pkg/mod/cache/download and then $HOME/go/pkg/mod/cache/download, matching the
+// layout "go mod download" itself uses.
+func moduleDownloadCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return filepath.Join(dir, "cache", "download")
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			gopath = filepath.Join(home, "go")
+		}
+	}
+	return filepath.Join(gopath, "pkg", "mod", "cache", "download")
+}
+
+// latestVersion resolves the latest published version of path via @latest.
+func (c *proxyClient) latestVersion(path string) (string, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := c.fetch(escaped, "@latest", escaped+"/@latest")
+	if err != nil {
+		return "", err
+	}
+
+	var info struct{ Version string }
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", fmt.Errorf("parsing @latest response for %s: %w", path, err)
+	}
+	if info.Version == "" {
+		return "", fmt.Errorf("@latest response for %s had no version", path)
+	}
+	return info.Version, nil
+}
+
+// latestModFile fetches and parses the go.mod of path at version.
+func (c *proxyClient) latestModFile(path, version string) (*modfile.File, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	name := escapedVersion + ".mod"
+	data, err := c.fetch(escaped, name, escaped+"/@v/"+name)
+	if err != nil {
+		return nil, err
+	}
+
+	return modfile.Parse(path+"@"+version+"/go.mod", data, nil)
+}
+
+// fetch returns the cached copy of escapedPath/cacheName if present, otherwise tries each
+// configured proxy in turn, caching the first successful response under
+// $GOMODCACHE/cache/download/<escapedPath>/@v/<cacheName>.
+func (c *proxyClient) fetch(escapedPath, cacheName, suffix string) ([]byte, error) {
+	cachePath := filepath.Join(c.cacheDir, escapedPath, "@v", cacheName)
+	if data, err := os.ReadFile(cachePath); err == nil {
+		return data, nil
+	}
+
+	var lastErr error
+	for _, proxy := range c.proxies {
+		switch proxy {
+		case "off":
+			return nil, errors.New("module downloads disabled by GOPROXY=off")
+		case "direct":
+			// Fetching directly from a VCS isn't implemented; skip to the next proxy, if any.
+			continue
+		}
+
+		url := strings.TrimSuffix(proxy, "/") + "/" + suffix
+		data, err := c.fetchOne(url)
+		if err != nil {
+			lastErr = err
+			if c.verbose {
+				fmt.Printf("proxy %s failed for %s: %v\n", proxy, suffix, err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+			_ = os.WriteFile(cachePath, data, 0o644)
+		}
+		return data, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no proxies configured")
+	}
+	return nil, lastErr
+}
+
+func (c *proxyClient) fetchOne(url string) ([]byte, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// checkDeprecations looks up the latest published go.mod for each path and returns the
+// "Deprecated:" message for any that declare one. Lookup failures (network errors, a proxy
+// that doesn't have the module, GOPROXY=off) are not fatal: the module is simply omitted
+// from the result, optionally logged under verbose.
+func checkDeprecations(paths []string, client *proxyClient, verbose bool) map[string]string {
+	deprecations := make(map[string]string)
+	for _, path := range paths {
+		version, err := client.latestVersion(path)
+		if err != nil {
+			if verbose {
+				fmt.Printf("could not resolve latest version for %s: %v\n", path, err)
+			}
+			continue
+		}
+
+		f, err := client.latestModFile(path, version)
+		if err != nil {
+			if verbose {
+				fmt.Printf("could not fetch go.mod for %s@%s: %v\n", path, version, err)
+			}
+			continue
+		}
+
+		if f.Module != nil && f.Module.Deprecated != "" {
+			deprecations[path] = f.Module.Deprecated
+		}
+	}
+	return deprecations
+}
+
+// checkModulesForDeprecation warns about (and, under failOnDeprecated, aborts on) any
+// module in modules whose latest published version is marked deprecated. It is a no-op
+// unless the caller already checked -offline.
+func checkModulesForDeprecation(modules map[string]string, verbose, failOnDeprecated bool) {
+	var paths []string
+	processMapOrdered(modules, func(path, version string) {
+		if version != "" {
+			paths = append(paths, path)
+		}
+	})
+	if len(paths) == 0 {
+		return
+	}
+
+	client := newProxyClient(verbose)
+	deprecations := checkDeprecations(paths, client, verbose)
+	if len(deprecations) == 0 {
+		return
+	}
+
+	// The warning itself is only emitted in verbose mode, except -fail-on-deprecated always
+	// prints it: otherwise a failure here would be silent and unexplained.
+	if verbose || failOnDeprecated {
+		for _, path := range paths {
+			if msg, ok := deprecations[path]; ok {
+				fmt.Fprintf(os.Stderr, "warning: pinning deprecated module %s: %s\n", path, msg)
+			}
+		}
+	}
+
+	if failOnDeprecated {
+		log.Fatalf("aborting: %d deprecated module(s) would be pinned (-fail-on-deprecated)", len(deprecations))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeProxy serves the minimal @latest/@v/<version>.mod protocol for one module, marking
+// it deprecated, so checkDeprecations can be exercised without reaching the real network.
+func newFakeProxy(t *testing.T, path, version, deprecatedMsg string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+path+"/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Version":%q}`, version)
+	})
+	mux.HandleFunc("/"+path+"/@v/"+version+".mod", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "// Deprecated: %s\nmodule %s\n", deprecatedMsg, path)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckDeprecations_FindsDeprecatedModule(t *testing.T) {
+	srv := newFakeProxy(t, "example.com/old", "v1.2.3", "use example.com/new instead")
+
+	client := &proxyClient{
+		httpClient: http.DefaultClient,
+		proxies:    []string{srv.URL},
+		cacheDir:   t.TempDir(),
+	}
+
+	deprecations := checkDeprecations([]string{"example.com/old"}, client, false)
+	if deprecations["example.com/old"] != "use example.com/new instead" {
+		t.Fatalf("unexpected deprecations: %+v", deprecations)
+	}
+}
+
+// A second lookup for the same module must be served from the on-disk cache, not the proxy.
+func TestProxyClient_CachesFetchedModFile(t *testing.T) {
+	srv := newFakeProxy(t, "example.com/cached", "v1.0.0", "")
+	cacheDir := t.TempDir()
+	client := &proxyClient{httpClient: http.DefaultClient, proxies: []string{srv.URL}, cacheDir: cacheDir}
+
+	if _, err := client.latestModFile("example.com/cached", "v1.0.0"); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+
+	srv.Close()
+
+	if _, err := client.latestModFile("example.com/cached", "v1.0.0"); err != nil {
+		t.Fatalf("second fetch should have hit the cache, got error: %v", err)
+	}
+}
+
+func TestParseGoProxy(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want []string
+	}{
+		{"", []string{"https://proxy.golang.org", "direct"}},
+		{"https://example.com,direct", []string{"https://example.com", "direct"}},
+		{"https://example.com,off,direct", []string{"https://example.com", "off"}},
+	}
+	for _, c := range cases {
+		got := parseGoProxy(c.raw)
+		if len(got) != len(c.want) {
+			t.Fatalf("parseGoProxy(%q) = %v, want %v", c.raw, got, c.want)
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Fatalf("parseGoProxy(%q) = %v, want %v", c.raw, got, c.want)
+			}
+		}
+	}
+}
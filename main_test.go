@@ -7,6 +7,8 @@ import (
 	"sort"
 	"strings"
 	"testing"
+
+	"golang.org/x/mod/modfile"
 )
 
 // Test that processMapOrdered iterates keys in lexicographic order.
@@ -87,27 +89,35 @@ require (
 		t.Fatalf("backup content mismatch")
 	}
 
-	// The new go.mod must contain the appended replace block without the default-excluded module.
+	// The new go.mod must be gofmt-canonical and contain a replace pinning pkg/errors,
+	// without a replace for the default-excluded dd-trace-go module.
 	newData, err := os.ReadFile(modPath)
 	if err != nil {
 		t.Fatalf("read modified go.mod: %v", err)
 	}
-	content := string(newData)
 
-	// It should include pkg/errors replacement and exclude dd-trace-go by default.
-	if !strings.Contains(content, "\n\n// prevent module upgrades\nreplace (") {
-		t.Fatalf("missing replace block header")
+	f, err := modfile.Parse(modPath, newData, nil)
+	if err != nil {
+		t.Fatalf("parse modified go.mod: %v", err)
 	}
-	if !strings.Contains(content, "\tgithub.com/pkg/errors => github.com/pkg/errors v0.9.1\n") {
-		t.Fatalf("missing expected replacement for pkg/errors")
+
+	var found bool
+	for _, r := range f.Replace {
+		if r.Old.Path == "gopkg.in/DataDog/dd-trace-go.v1" {
+			t.Fatalf("default-excluded module appears in replace block")
+		}
+		if r.Old.Path == "github.com/pkg/errors" {
+			if r.New.Path != "github.com/pkg/errors" || r.New.Version != "v0.9.1" {
+				t.Fatalf("unexpected replacement for pkg/errors: %+v", r.New)
+			}
+			found = true
+		}
 	}
-	// Only search within the appended replace block, not the original require section.
-	headerIdx := strings.Index(content, "\n\n// prevent module upgrades\nreplace (")
-	tail := content
-	if headerIdx >= 0 {
-		tail = content[headerIdx:]
+	if !found {
+		t.Fatalf("missing expected replacement for pkg/errors")
 	}
-	if strings.Contains(tail, "dd-trace-go") {
-		t.Fatalf("default-excluded module appears in replace block")
+
+	if !strings.Contains(string(newData), "// prevent module upgrades") {
+		t.Fatalf("missing pin marker comment")
 	}
 }
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// stringSliceFlag implements flag.Value to allow repeating -exclude flags.
+// Why: Users often need to exclude more than one module; repeating the flag is ergonomic
+// and avoids inventing custom separators.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string { return fmt.Sprintf("%v", []string(*s)) }
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// defaultExcludes lists the module paths gomodpin skips unless -no-default-excludes is set.
+// Why: These modules are intentionally managed independently and should not be pinned
+// by this tool unless explicitly desired.
+var defaultExcludes = []string{
+	"gopkg.in/DataDog/dd-trace-go.v1",
+	"github.com/DataDog/dd-trace-go/v2",
+	"github.com/DataDog/orchestrion",
+}
+
+// pinMarker is the comment gomodpin attaches to the first replace directive it adds, so the
+// intent of the managed block stays obvious even after merging into an existing replace block.
+const pinMarker = "// prevent module upgrades"
+
+// buildExcludeSet combines the built-in default excludes with any user-provided -exclude
+// flags into a single set.
+func buildExcludeSet(noDefaultExcludes bool, userExcludes []string) map[string]struct{} {
+	excludes := defaultExcludes
+	if noDefaultExcludes {
+		excludes = nil
+	}
+
+	excludeSet := make(map[string]struct{})
+	for _, p := range excludes {
+		excludeSet[p] = struct{}{}
+	}
+	for _, p := range userExcludes {
+		excludeSet[p] = struct{}{}
+	}
+	return excludeSet
+}
+
+// computeModules builds a map of modules to the versions a single go.mod would pin. It
+// starts with the require list, then adjusts per that file's own replace/exclude directives.
+func computeModules(f *modfile.File, verbose bool) map[string]string {
+	modules := make(map[string]string)
+	for _, require := range f.Require {
+		modules[require.Mod.Path] = require.Mod.Version
+	}
+
+	// Apply replace directives:
+	// - If a replace points to a different module path, drop the original from consideration.
+	// - If it is a self-replace (same path), override the version to pin to the replaced version.
+	for _, replace := range f.Replace {
+		if replace.Old.Path != replace.New.Path {
+			delete(modules, replace.Old.Path)
+		} else {
+			if verbose {
+				fmt.Printf("Replacing: %s with %s\n", replace.Old.Path, replace.New.Version)
+			}
+			modules[replace.Old.Path] = replace.New.Version
+		}
+	}
+
+	// Apply exclude directives from go.mod: remove excluded modules entirely.
+	for _, exclude := range f.Exclude {
+		if version, exists := modules[exclude.Mod.Path]; exists {
+			if verbose {
+				fmt.Printf("Excluding (from go.mod exclude) %s@%s\n", exclude.Mod.Path, version)
+			}
+			delete(modules, exclude.Mod.Path)
+		}
+	}
+
+	return modules
+}
+
+// hasPinMarker reports whether r carries the pinMarker comment gomodpin attaches to the
+// first replace directive it adds.
+func hasPinMarker(r *modfile.Replace) bool {
+	if r.Syntax == nil {
+		return false
+	}
+	for _, c := range r.Syntax.Comment().Before {
+		if strings.TrimSpace(c.Token) == pinMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedSelfReplace reports whether r is a replace directive gomodpin's own pin logic
+// would treat as a pin rather than an override: an unconditional self-replace (Old.Path ==
+// New.Path), the same shape computeModules/computeExpectedModules already special-case.
+// Anything else -- a dependency swap or a local directory override to a different path -- is
+// left for the user to manage directly.
+func isManagedSelfReplace(r *modfile.Replace) bool {
+	return r.Old.Path == r.New.Path
+}
+
+// findReplace returns the first replace directive in f for the given old path, regardless of
+// shape, or nil if none exists. Used by `edit` to detect an unmanaged replace before touching it.
+func findReplace(f *modfile.File, path string) *modfile.Replace {
+	for _, r := range f.Replace {
+		if r.Old.Path == path {
+			return r
+		}
+	}
+	return nil
+}
+
+// computeExpectedModules builds the modules map gomodpin *should* pin right now: like
+// computeModules, but it ignores gomodpin's own prior self-replaces (identified by
+// pinMarker) so a require version bump isn't masked by the stale pin it left behind.
+// Hand-written self-replaces (without the marker) still override, exactly as computeModules
+// does, since those represent an intentional pin this tool didn't write.
+func computeExpectedModules(f *modfile.File, verbose bool) map[string]string {
+	modules := make(map[string]string)
+	for _, require := range f.Require {
+		modules[require.Mod.Path] = require.Mod.Version
+	}
+
+	for _, replace := range f.Replace {
+		if replace.Old.Path != replace.New.Path {
+			delete(modules, replace.Old.Path)
+			continue
+		}
+		if hasPinMarker(replace) {
+			continue
+		}
+		if verbose {
+			fmt.Printf("Replacing: %s with %s\n", replace.Old.Path, replace.New.Version)
+		}
+		modules[replace.Old.Path] = replace.New.Version
+	}
+
+	for _, exclude := range f.Exclude {
+		if version, exists := modules[exclude.Mod.Path]; exists {
+			if verbose {
+				fmt.Printf("Excluding (from go.mod exclude) %s@%s\n", exclude.Mod.Path, version)
+			}
+			delete(modules, exclude.Mod.Path)
+		}
+	}
+
+	return modules
+}
+
+// applyExcludeSet removes every module path present in excludeSet from modules.
+func applyExcludeSet(modules map[string]string, excludeSet map[string]struct{}, verbose bool) {
+	for p := range excludeSet {
+		if _, ok := modules[p]; ok {
+			if verbose {
+				fmt.Printf("Excluding (from flags) %s\n", p)
+			}
+			delete(modules, p)
+		}
+	}
+}
+
+// pinModule writes a backup of modPath to go.mod.old, then applies the pins in modules to f
+// via AddReplace, which merges into (and dedupes against) any existing replace block rather
+// than appending raw text. It marks the first pin it adds with pinMarker so the intent of the
+// block is still obvious. Entries with an empty version are skipped to avoid noise. It
+// returns the number of replacements added.
+func pinModule(modPath string, data []byte, info os.FileInfo, f *modfile.File, modules map[string]string, verbose bool) (int, error) {
+	count := 0
+	var marked bool
+	processMapOrdered(modules, func(path, newVersion string) {
+		if newVersion == "" {
+			if verbose {
+				fmt.Printf("Skipping %s due to empty version\n", path)
+			}
+			return
+		}
+		if err := f.AddReplace(path, "", path, newVersion); err != nil {
+			log.Fatalf("error adding replace for %s: %v", path, err)
+		}
+		count++
+		if !marked {
+			markPinComment(f, path)
+			marked = true
+		}
+	})
+
+	// Safety: write a full backup before mutating go.mod.
+	backupPath := filepath.Join(filepath.Dir(modPath), "go.mod.old")
+	if err := os.WriteFile(backupPath, data, info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("error writing backup file: %w", err)
+	}
+	if verbose {
+		fmt.Printf("Backed up %s to %s\n", modPath, backupPath)
+	}
+
+	// If there is nothing to pin, stop after producing the backup.
+	if count == 0 {
+		return 0, nil
+	}
+
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		return 0, fmt.Errorf("error formatting go.mod: %w", err)
+	}
+
+	if err := writeFileAtomically(modPath, out, info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("error writing go.mod: %w", err)
+	}
+	return count, nil
+}
+
+// markPinComment annotates the replace directive for path with a leading pinMarker comment,
+// unless it already carries one.
+func markPinComment(f *modfile.File, path string) {
+	for _, r := range f.Replace {
+		if r.Old.Path == path && r.Syntax != nil {
+			if hasPinMarker(r) {
+				return
+			}
+			com := r.Syntax.Comment()
+			com.Before = append(com.Before, modfile.Comment{Token: pinMarker})
+			return
+		}
+	}
+}
+
+// writeFileAtomically writes data to a temp file in the same directory as path, then
+// renames it into place, so a crash or interrupted write never leaves a truncated go.mod.
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// processMapOrdered calls f for each key/value in m with keys sorted lexicographically.
+// Why: Deterministic ordering makes generated output stable, which simplifies reviews
+// and future diffs.
+func processMapOrdered(m map[string]string, f func(key, value string)) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f(key, m[key])
+	}
+}
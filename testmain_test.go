@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain forces GOPROXY=off for the whole test binary, so runPin's deprecation check
+// (which reads GOPROXY itself) never reaches the real network in tests that don't pass
+// -offline. Tests exercising the proxy client construct one directly against an httptest
+// server instead, so they are unaffected by this override.
+func TestMain(m *testing.M) {
+	os.Setenv("GOPROXY", "off")
+	os.Exit(m.Run())
+}
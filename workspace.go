@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// workspaceModule holds everything pinModule needs for one module used by a go.work file,
+// plus the modules map computed from that module's own go.mod.
+type workspaceModule struct {
+	path    string
+	data    []byte
+	info    os.FileInfo
+	file    *modfile.File
+	modules map[string]string
+}
+
+// pinWorkspace pins every module a go.work file uses. In independent mode (the default)
+// each module is pinned against its own requires, exactly as if gomodpin had been run on it
+// directly. Under crossReconcile (-workspace) the union of required modules across every
+// go.mod is computed first, a single winning version per module path is picked (highest by
+// semver, with go.work-level replace directives taking precedence), and that same pinned
+// replace block is written into every module so the workspace resolves deterministically.
+// Unless offline is set, the same deprecation check runPin applies to a single go.mod is run
+// here too -- once per module in independent mode, once against the reconciled union
+// otherwise -- so -fail-on-deprecated aborts a workspace pin exactly as it would a plain one.
+func pinWorkspace(workPath string, verbose bool, crossReconcile bool, excludeSet map[string]struct{}, offline bool, failOnDeprecated bool) error {
+	data, err := os.ReadFile(workPath)
+	if err != nil {
+		return fmt.Errorf("error reading go.work: %w", err)
+	}
+
+	wf, err := modfile.ParseWork(workPath, data, nil)
+	if err != nil {
+		return fmt.Errorf("error parsing go.work: %w", err)
+	}
+
+	baseDir := filepath.Dir(workPath)
+
+	modules := make([]workspaceModule, 0, len(wf.Use))
+	for _, use := range wf.Use {
+		modDir := filepath.Join(baseDir, use.Path)
+		modPath := filepath.Join(modDir, "go.mod")
+
+		info, err := os.Stat(modPath)
+		if err != nil {
+			return fmt.Errorf("error accessing %s: %w", modPath, err)
+		}
+
+		data, err := os.ReadFile(modPath)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", modPath, err)
+		}
+
+		f, err := modfile.Parse(modPath, data, nil)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", modPath, err)
+		}
+
+		modules = append(modules, workspaceModule{
+			path:    modPath,
+			data:    data,
+			info:    info,
+			file:    f,
+			modules: computeModules(f, verbose),
+		})
+	}
+
+	if crossReconcile {
+		union := reconcileWorkspaceVersions(wf, modules, verbose)
+		applyExcludeSet(union, excludeSet, verbose)
+		if !offline {
+			checkModulesForDeprecation(union, verbose, failOnDeprecated)
+		}
+
+		total := 0
+		for _, m := range modules {
+			count, err := pinModule(m.path, m.data, m.info, m.file, union, verbose)
+			if err != nil {
+				return fmt.Errorf("error pinning %s: %w", m.path, err)
+			}
+			total += count
+		}
+		if verbose {
+			fmt.Printf("Added %d replacements across %d workspace modules\n", total, len(modules))
+		}
+		return nil
+	}
+
+	total := 0
+	for _, m := range modules {
+		applyExcludeSet(m.modules, excludeSet, verbose)
+		if !offline {
+			checkModulesForDeprecation(m.modules, verbose, failOnDeprecated)
+		}
+		count, err := pinModule(m.path, m.data, m.info, m.file, m.modules, verbose)
+		if err != nil {
+			return fmt.Errorf("error pinning %s: %w", m.path, err)
+		}
+		total += count
+	}
+	if verbose {
+		fmt.Printf("Added %d replacements across %d workspace modules\n", total, len(modules))
+	}
+	return nil
+}
+
+// reconcileWorkspaceVersions computes the union of required modules across every module in
+// the workspace, picking the highest version by semver.Compare for each module path. A
+// go.work-level replace directive wins outright over the semver winner: a self-replace
+// (same old/new path) pins its version, while a replace to a different path removes that
+// module from the union since it is resolved locally and gomodpin has no version to pin.
+func reconcileWorkspaceVersions(wf *modfile.WorkFile, modules []workspaceModule, verbose bool) map[string]string {
+	union := make(map[string]string)
+	for _, m := range modules {
+		for path, version := range m.modules {
+			if version == "" {
+				continue
+			}
+			if current, ok := union[path]; !ok || semver.Compare(version, current) > 0 {
+				union[path] = version
+			}
+		}
+	}
+
+	for _, replace := range wf.Replace {
+		if replace.Old.Path != replace.New.Path {
+			if verbose {
+				fmt.Printf("Dropping %s from workspace reconciliation: locally replaced by go.work\n", replace.Old.Path)
+			}
+			delete(union, replace.Old.Path)
+			continue
+		}
+		if verbose {
+			fmt.Printf("Workspace replace: %s pinned to %s\n", replace.Old.Path, replace.New.Version)
+		}
+		union[replace.Old.Path] = replace.New.Version
+	}
+
+	return union
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+// writeWorkspaceFixture lays out a two-module workspace where both modules require
+// different versions of the same dependency, returning the go.work path.
+func writeWorkspaceFixture(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	mustWrite := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	mustWrite(filepath.Join(root, "go.work"), `go 1.23.2
+
+use (
+	./a
+	./b
+)
+`)
+	mustWrite(filepath.Join(root, "a", "go.mod"), `module example.com/a
+
+go 1.23.2
+
+require github.com/pkg/errors v0.9.0
+`)
+	mustWrite(filepath.Join(root, "b", "go.mod"), `module example.com/b
+
+go 1.23.2
+
+require github.com/pkg/errors v0.9.1
+`)
+
+	return filepath.Join(root, "go.work")
+}
+
+// Integration-style test: -workspace reconciles the two modules to the higher shared version
+// and writes the same pin into both go.mod files.
+func TestMain_Workspace_ReconcilesSharedVersion(t *testing.T) {
+	workPath := writeWorkspaceFixture(t)
+	root := filepath.Dir(workPath)
+
+	runMainWithArgs(t, []string{"-workspace", workPath})
+
+	for _, mod := range []string{"a", "b"} {
+		modPath := filepath.Join(root, mod, "go.mod")
+		data, err := os.ReadFile(modPath)
+		if err != nil {
+			t.Fatalf("read %s: %v", modPath, err)
+		}
+		f, err := modfile.Parse(modPath, data, nil)
+		if err != nil {
+			t.Fatalf("parse %s: %v", modPath, err)
+		}
+
+		var found bool
+		for _, r := range f.Replace {
+			if r.Old.Path == "github.com/pkg/errors" {
+				if r.New.Version != "v0.9.1" {
+					t.Fatalf("%s: expected reconciled version v0.9.1, got %s", modPath, r.New.Version)
+				}
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s: missing replacement for pkg/errors", modPath)
+		}
+
+		if _, err := os.Stat(filepath.Join(root, mod, "go.mod.old")); err != nil {
+			t.Fatalf("%s: missing backup: %v", modPath, err)
+		}
+	}
+}
+
+// Without -workspace, each module is pinned independently to its own required version.
+func TestMain_Workspace_IndependentPinsPerModuleVersion(t *testing.T) {
+	workPath := writeWorkspaceFixture(t)
+	root := filepath.Dir(workPath)
+
+	runMainWithArgs(t, []string{workPath})
+
+	wantVersion := map[string]string{"a": "v0.9.0", "b": "v0.9.1"}
+	for mod, want := range wantVersion {
+		modPath := filepath.Join(root, mod, "go.mod")
+		data, err := os.ReadFile(modPath)
+		if err != nil {
+			t.Fatalf("read %s: %v", modPath, err)
+		}
+		f, err := modfile.Parse(modPath, data, nil)
+		if err != nil {
+			t.Fatalf("parse %s: %v", modPath, err)
+		}
+
+		var found bool
+		for _, r := range f.Replace {
+			if r.Old.Path == "github.com/pkg/errors" {
+				if r.New.Version != want {
+					t.Fatalf("%s: expected %s, got %s", modPath, want, r.New.Version)
+				}
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s: missing replacement for pkg/errors", modPath)
+		}
+	}
+}
+
+// -v on a go.work target must still run the deprecation check and warn on stderr: the flag
+// must not be silently ignored just because the target is a workspace.
+func TestMain_Workspace_WarnsOnDeprecatedModule(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/github.com/pkg/errors/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version":"v0.9.1"}`)
+	})
+	mux.HandleFunc("/github.com/pkg/errors/@v/v0.9.1.mod", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "// Deprecated: use errors/fmt instead\nmodule github.com/pkg/errors\n")
+	})
+	mux.HandleFunc("/github.com/pkg/errors/@v/v0.9.0.mod", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "// Deprecated: use errors/fmt instead\nmodule github.com/pkg/errors\n")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("GOPROXY", srv.URL)
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	workPath := writeWorkspaceFixture(t)
+
+	stderr := captureStderr(t, func() { runPin([]string{"-v", workPath}) })
+
+	if !strings.Contains(stderr, "warning: pinning deprecated module github.com/pkg/errors: use errors/fmt instead") {
+		t.Fatalf("missing deprecation warning in stderr for workspace pin: %q", stderr)
+	}
+}